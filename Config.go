@@ -1,9 +1,22 @@
 package firestoreadapter
 
+import "time"
+
 type Config struct {
 	// Firestore collection name.
 	// Optional. (Default: "casbin")
 	Collection string
+
+	// Cache, if set, lets LoadPolicy skip a Firestore read of the whole
+	// collection when nothing has changed since the last load. Optional.
+	Cache Cache
+
+	// CacheTTL bounds how long a cached LoadPolicy result is trusted before
+	// re-checking Firestore for a newer write, via a single
+	// OrderBy("update_time", Desc).Limit(1) query. Zero means always
+	// re-check (a full LoadPolicy read is still skipped when nothing
+	// changed). Ignored if Cache is nil.
+	CacheTTL time.Duration
 }
 
 func (c Config) collectionName() string {
@@ -2,7 +2,13 @@ package firestoreadapter
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/casbin/casbin/v2/model"
@@ -12,21 +18,55 @@ import (
 
 const defaultCollectionName = "casbin"
 
-// CasbinRule represents a rule in Casbin.
+// firestoreInLimit is the maximum number of values Firestore accepts in a
+// single "in" clause.
+const firestoreInLimit = 10
+
+// maxTransactionWrites is the maximum number of writes Firestore accepts
+// within a single transaction.
+const maxTransactionWrites = 500
+
+// CasbinRule represents a rule in Casbin. Values holds the rule's fields
+// keyed by position ("0", "1", ...) rather than a fixed v0..v5 set, so a
+// rule of any arity can be stored (e.g. models that add a 6th+ field such as
+// "service" in the RBAC-with-domains examples). N records the arity so a
+// rule with trailing empty-string fields round-trips correctly.
 type CasbinRule struct {
-	PType string `firestore:"p_type"`
-	V0    string `firestore:"v0"`
-	V1    string `firestore:"v1"`
-	V2    string `firestore:"v2"`
-	V3    string `firestore:"v3"`
-	V4    string `firestore:"v4"`
-	V5    string `firestore:"v5"`
+	PType  string            `firestore:"p_type"`
+	N      int               `firestore:"n"`
+	Values map[string]string `firestore:"values"`
+
+	// UpdateTime is set by Firestore on every write. It backs the
+	// Config.Cache staleness check: LoadPolicy compares the greatest
+	// UpdateTime it cached against the latest one currently in the
+	// collection before deciding whether to refetch.
+	UpdateTime time.Time `firestore:"update_time,serverTimestamp"`
 }
 
 // adapter represents the GCP firestore adapter for policy storage.
 type adapter struct {
-	client    *firestore.Client
+	client     *firestore.Client
 	collection string
+	filtered   bool
+	cache      Cache
+	cacheTTL   time.Duration
+}
+
+// Filter defines the values used to restrict LoadFilteredPolicy to a subset
+// of rules. A nil or zero-value slice for a field means "don't filter on
+// this field", and at most one field may be non-empty. This is primarily
+// useful for multi-tenant deployments where a single collection holds
+// policies for many tenants (e.g. filtering on V0 when it carries the domain
+// in an RBAC-with-domains model) and each enforcer should only load its own
+// slice.
+type Filter struct {
+	PType []string
+	V0    []string
+	V1    []string
+	V2    []string
+	V3    []string
+	V4    []string
+	V5    []string
 }
 
 // finalizer is the destructor for adapter.
@@ -45,7 +85,12 @@ func NewAdapter(db *firestore.Client) persist.Adapter {
 
 // NewAdapter is the constructor for Adapter. A valid firestore client must be provided.
 func NewAdapterWithConfig(db *firestore.Client, config Config) persist.Adapter {
-	a := &adapter{db, config.collectionName()}
+	a := &adapter{
+		client:     db,
+		collection: config.collectionName(),
+		cache:      config.Cache,
+		cacheTTL:   config.CacheTTL,
+	}
 
 	// Call the destructor when the object is released.
 	runtime.SetFinalizer(a, finalizer)
@@ -58,10 +103,236 @@ func (a *adapter) newQuery() firestore.Query {
 }
 
 func (a *adapter) LoadPolicy(model model.Model) error {
-	var rules []CasbinRule
+	ctx := context.Background()
+
+	if rules, ok := a.loadFromCache(ctx); ok {
+		for _, rule := range rules {
+			loadPolicyLine(rule, model)
+		}
+		a.filtered = false
+		return nil
+	}
+
+	rules, err := a.loadQuery(ctx, a.newQuery())
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		loadPolicyLine(rule, model)
+	}
+
+	if a.cache != nil {
+		a.cache.Set(a.collection, rules, maxUpdateTime(rules), time.Now())
+	}
+
+	a.filtered = false
+	return nil
+}
+
+// loadFromCache returns the cached rules for this collection if, and only
+// if, Config.Cache is set and either CacheTTL hasn't elapsed since they were
+// cached or a single OrderBy("update_time", Desc).Limit(1) query confirms
+// nothing newer has been written since.
+func (a *adapter) loadFromCache(ctx context.Context) ([]CasbinRule, bool) {
+	if a.cache == nil {
+		return nil, false
+	}
+
+	rules, updateTime, cachedAt, ok := a.cache.Get(a.collection)
+	if !ok {
+		return nil, false
+	}
+
+	if a.cacheTTL > 0 && time.Since(cachedAt) < a.cacheTTL {
+		return rules, true
+	}
+
+	latest, err := a.latestUpdateTime(ctx)
+	if err != nil || latest.After(updateTime) {
+		return nil, false
+	}
+	return rules, true
+}
+
+// latestUpdateTime returns the most recent update_time among this
+// collection's policy documents, or the zero Time if the collection is
+// empty. It queries the raw collection rather than newQuery(), since
+// Firestore requires a query's first OrderBy to be on the same field as any
+// inequality filter, and the "conf" document newQuery() excludes has no
+// update_time field to order by anyway, so it's dropped from these results
+// regardless.
+func (a *adapter) latestUpdateTime(ctx context.Context) (time.Time, error) {
+	iter := a.client.Collection(a.collection).OrderBy("update_time", firestore.Desc).Limit(1).Documents(ctx)
+	doc, err := iter.Next()
+	if err == iterator.Done {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	var rule CasbinRule
+	if err = doc.DataTo(&rule); err != nil {
+		return time.Time{}, err
+	}
+	return rule.UpdateTime, nil
+}
+
+// maxUpdateTime returns the greatest UpdateTime among rules, or the zero
+// Time if rules is empty.
+func maxUpdateTime(rules []CasbinRule) time.Time {
+	var max time.Time
+	for _, rule := range rules {
+		if rule.UpdateTime.After(max) {
+			max = rule.UpdateTime
+		}
+	}
+	return max
+}
+
+// invalidateCache discards this collection's cached entry, called after a
+// write this adapter makes so the cache never serves data the adapter
+// itself just made stale.
+func (a *adapter) invalidateCache() {
+	if a.cache != nil {
+		a.cache.Invalidate(a.collection)
+	}
+}
+
+// IsFiltered returns true if the last LoadPolicy/LoadFilteredPolicy call
+// loaded a subset of the collection rather than every rule.
+func (a *adapter) IsFiltered() bool {
+	return a.filtered
+}
+
+// LoadFilteredPolicy loads only the rules matching filter into model. filter
+// must be a Filter (or nil, in which case it behaves like LoadPolicy), with
+// at most one of its fields non-empty: that field becomes a Firestore "in"
+// clause, split into chunks of at most firestoreInLimit values that are
+// queried in parallel and merged. A Filter with no field set loads every
+// rule, and IsFiltered then reports false. The base query omits newQuery()'s
+// p_type > "" filter, since Firestore rejects an "in" clause on the same
+// field as an existing inequality (and the "in" clause excludes the
+// non-policy "conf" document on its own, the same as the inequality would
+// have). Constraining more than one field at once is rejected outright,
+// since stacking multiple "in" clauses in one query isn't supported by every
+// Firestore client version.
+func (a *adapter) LoadFilteredPolicy(model model.Model, filter interface{}) error {
+	if filter == nil {
+		return a.LoadPolicy(model)
+	}
+
+	f, ok := filter.(Filter)
+	if !ok {
+		return errors.New("invalid filter type, expected firestoreadapter.Filter")
+	}
+
+	queries := []firestore.Query{a.client.Collection(a.collection).Query}
+	constrained := false
+	for _, field := range []struct {
+		name   string
+		values []string
+	}{
+		{"p_type", f.PType},
+		{"values.0", f.V0},
+		{"values.1", f.V1},
+		{"values.2", f.V2},
+		{"values.3", f.V3},
+		{"values.4", f.V4},
+		{"values.5", f.V5},
+	} {
+		if len(field.values) == 0 {
+			continue
+		}
+		if constrained {
+			return errors.New("firestoreadapter: Filter supports constraining only one field at a time")
+		}
+		queries = expandQueriesWithIn(queries, field.name, field.values)
+		constrained = true
+	}
+	if !constrained {
+		queries = []firestore.Query{a.newQuery()}
+	}
 
 	ctx := context.Background()
-	query := a.newQuery()
+	rules, err := a.loadQueriesMerged(ctx, queries)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		loadPolicyLine(rule, model)
+	}
+
+	a.filtered = constrained
+	return nil
+}
+
+// expandQueriesWithIn returns one query per (existing query, value chunk)
+// combination, each constrained with a Where(field, "in", chunk) clause.
+func expandQueriesWithIn(queries []firestore.Query, field string, values []string) []firestore.Query {
+	var expanded []firestore.Query
+	for _, q := range queries {
+		for _, chunk := range chunkStrings(values, firestoreInLimit) {
+			in := make([]interface{}, len(chunk))
+			for i, v := range chunk {
+				in[i] = v
+			}
+			expanded = append(expanded, q.Where(field, "in", in))
+		}
+	}
+	return expanded
+}
+
+func chunkStrings(values []string, size int) [][]string {
+	var chunks [][]string
+	for len(values) > size {
+		chunks = append(chunks, values[:size:size])
+		values = values[size:]
+	}
+	return append(chunks, values)
+}
+
+// loadQueriesMerged runs queries in parallel and returns the de-duplicated
+// union of the rules they match.
+func (a *adapter) loadQueriesMerged(ctx context.Context, queries []firestore.Query) ([]CasbinRule, error) {
+	results := make([][]CasbinRule, len(queries))
+	errs := make([]error, len(queries))
+
+	var wg sync.WaitGroup
+	wg.Add(len(queries))
+	for i, q := range queries {
+		go func(i int, q firestore.Query) {
+			defer wg.Done()
+			results[i], errs[i] = a.loadQuery(ctx, q)
+		}(i, q)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	var rules []CasbinRule
+	for i, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range results[i] {
+			key := ruleKey(rule)
+			if !seen[key] {
+				seen[key] = true
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// ruleKey returns a string uniquely identifying a rule's ptype and values,
+// for de-duplicating rows matched by more than one chunked query.
+func ruleKey(line CasbinRule) string {
+	return line.PType + "\x00" + strings.Join(ruleTokens(line), "\x00")
+}
+
+func (a *adapter) loadQuery(ctx context.Context, query firestore.Query) ([]CasbinRule, error) {
+	var rules []CasbinRule
+
 	iter := query.Documents(ctx)
 	for {
 		doc, err := iter.Next()
@@ -69,19 +340,16 @@ func (a *adapter) LoadPolicy(model model.Model) error {
 			break
 		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 		var rule CasbinRule
 		if err = doc.DataTo(&rule); err != nil {
-			return err
+			return nil, err
 		}
 		rules = append(rules, rule)
 	}
-	for _, rule := range rules {
-		loadPolicyLine(rule, model)
-	}
 
-	return nil
+	return rules, nil
 }
 
 func (a *adapter) SavePolicy(model model.Model) error {
@@ -126,8 +394,12 @@ func (a *adapter) SavePolicy(model model.Model) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	a.invalidateCache()
+	return nil
 }
 
 func (a *adapter) AddPolicy(sec string, ptype string, rule []string) error {
@@ -137,83 +409,209 @@ func (a *adapter) AddPolicy(sec string, ptype string, rule []string) error {
 	err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
 		return tx.Create(policies.NewDoc(), &line)
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	a.invalidateCache()
+	return nil
 }
 
 func (a *adapter) RemovePolicy(sec string, ptype string, rule []string) error {
 	line := savePolicyLine(ptype, rule)
+	q := a.ruleQuery(line)
 
 	ctx := context.Background()
-	q := a.newQuery().
-		Where("p_type", "==", line.PType).
-		Where("v0", "==", line.V0).
-		Where("v1", "==", line.V1).
-		Where("v2", "==", line.V2).
-		Where("v3", "==", line.V3).
-		Where("v4", "==", line.V4)
-
-	return a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
-		iter := tx.Documents(q)
-		for {
-			doc, err := iter.Next()
-			if err == iterator.Done {
-				break
-			}
-			if err != nil {
-				return err
-			}
-			if err = tx.Delete(doc.Ref); err != nil {
-				return err
-			}
-		}
-		return nil
+	err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return a.deleteMatching(ctx, tx, q)
 	})
+	if err != nil {
+		return err
+	}
+
+	a.invalidateCache()
+	return nil
 }
 
 func (a *adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
-
 	ctx := context.Background()
 
-	selector := make(map[string]interface{})
-	selector["p_type"] = ptype
+	q := a.newQuery().Where("p_type", "==", ptype)
+	for k, v := range filterSelector(fieldIndex, fieldValues) {
+		q = q.Where(k, "==", v)
+	}
 
-	if fieldIndex <= 0 && 0 < fieldIndex+len(fieldValues) {
-		if fieldValues[0-fieldIndex] != "" {
-			selector["v0"] = fieldValues[0-fieldIndex]
-		}
+	err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return a.deleteMatching(ctx, tx, q)
+	})
+	if err != nil {
+		return err
 	}
-	if fieldIndex <= 1 && 1 < fieldIndex+len(fieldValues) {
-		if fieldValues[1-fieldIndex] != "" {
-			selector["v1"] = fieldValues[1-fieldIndex]
+
+	a.invalidateCache()
+	return nil
+}
+
+// filterSelector builds the values.N equality selector used by
+// RemoveFilteredPolicy and UpdateFilteredPolicies from Casbin's
+// (fieldIndex, fieldValues) encoding: fieldValues[i] constrains field
+// fieldIndex+i, and an empty value leaves that field unconstrained. Unlike a
+// fixed v0..v5 layout this places no limit on the field index, so it works
+// for rules of any arity.
+func filterSelector(fieldIndex int, fieldValues []string) map[string]interface{} {
+	selector := make(map[string]interface{})
+	for i, v := range fieldValues {
+		if v != "" {
+			selector[fmt.Sprintf("values.%d", fieldIndex+i)] = v
 		}
 	}
-	if fieldIndex <= 2 && 2 < fieldIndex+len(fieldValues) {
-		if fieldValues[2-fieldIndex] != "" {
-			selector["v2"] = fieldValues[2-fieldIndex]
-		}
+	return selector
+}
+
+// AddPolicies adds rules in chunked transactions of at most
+// maxTransactionWrites so large batches stay atomic per chunk instead of
+// round-tripping one document at a time.
+func (a *adapter) AddPolicies(sec string, ptype string, rules [][]string) error {
+	lines := make([]CasbinRule, len(rules))
+	for i, rule := range rules {
+		lines[i] = savePolicyLine(ptype, rule)
 	}
-	if fieldIndex <= 3 && 3 < fieldIndex+len(fieldValues) {
-		if fieldValues[3-fieldIndex] != "" {
-			selector["v3"] = fieldValues[3-fieldIndex]
+
+	ctx := context.Background()
+	policies := a.client.Collection(a.collection)
+	for _, chunk := range chunkRuleLines(lines, maxTransactionWrites) {
+		chunk := chunk
+		err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			for _, line := range chunk {
+				line := line
+				if err := tx.Create(policies.NewDoc(), &line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
-	if fieldIndex <= 4 && 4 < fieldIndex+len(fieldValues) {
-		if fieldValues[4-fieldIndex] != "" {
-			selector["v4"] = fieldValues[4-fieldIndex]
+
+	a.invalidateCache()
+	return nil
+}
+
+// RemovePolicies removes rules in chunked transactions of at most
+// maxTransactionWrites. Each chunk first queries every rule's matching docs,
+// then deletes them, since the Firestore client requires all of a
+// transaction's reads to happen before any of its writes: querying one rule
+// at a time and deleting as each match comes back would issue a new read
+// after the previous rule's delete was already buffered.
+func (a *adapter) RemovePolicies(sec string, ptype string, rules [][]string) error {
+	lines := make([]CasbinRule, len(rules))
+	for i, rule := range rules {
+		lines[i] = savePolicyLine(ptype, rule)
+	}
+
+	ctx := context.Background()
+	for _, chunk := range chunkRuleLines(lines, maxTransactionWrites) {
+		chunk := chunk
+		err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			var refs []*firestore.DocumentRef
+			for _, line := range chunk {
+				matched, err := a.matchingRefs(ctx, tx, a.ruleQuery(line))
+				if err != nil {
+					return err
+				}
+				refs = append(refs, matched...)
+			}
+			for _, ref := range refs {
+				if err := tx.Delete(ref); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
-	if fieldIndex <= 5 && 5 < fieldIndex+len(fieldValues) {
-		if fieldValues[5-fieldIndex] != "" {
-			selector["v5"] = fieldValues[5-fieldIndex]
+
+	a.invalidateCache()
+	return nil
+}
+
+// UpdatePolicy replaces oldRule with newPolicy.
+func (a *adapter) UpdatePolicy(sec string, ptype string, oldRule, newPolicy []string) error {
+	return a.UpdatePolicies(sec, ptype, [][]string{oldRule}, [][]string{newPolicy})
+}
+
+// UpdatePolicies replaces each oldRules[i] with newRules[i], in chunked
+// transactions of at most maxTransactionWrites/2 rules, since each rule
+// costs one delete and one create. Each chunk queries every old rule's
+// matching doc before creating any new one, for the same read-before-write
+// reason as RemovePolicies.
+func (a *adapter) UpdatePolicies(sec string, ptype string, oldRules, newRules [][]string) error {
+	oldLines := make([]CasbinRule, len(oldRules))
+	for i, rule := range oldRules {
+		oldLines[i] = savePolicyLine(ptype, rule)
+	}
+	newLines := make([]CasbinRule, len(newRules))
+	for i, rule := range newRules {
+		newLines[i] = savePolicyLine(ptype, rule)
+	}
+
+	ctx := context.Background()
+	policies := a.client.Collection(a.collection)
+	for _, batch := range chunkIndices(len(oldLines), maxTransactionWrites/2) {
+		batch := batch
+		err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			var refs []*firestore.DocumentRef
+			for _, i := range batch {
+				matched, err := a.matchingRefs(ctx, tx, a.ruleQuery(oldLines[i]))
+				if err != nil {
+					return err
+				}
+				refs = append(refs, matched...)
+			}
+			for _, ref := range refs {
+				if err := tx.Delete(ref); err != nil {
+					return err
+				}
+			}
+			for _, i := range batch {
+				line := newLines[i]
+				if err := tx.Create(policies.NewDoc(), &line); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
 		}
 	}
 
-	q := a.newQuery()
-	for k, v := range selector {
+	a.invalidateCache()
+	return nil
+}
+
+// UpdateFilteredPolicies replaces every rule matching fieldIndex/fieldValues
+// with newPolicies and returns the rules that were replaced.
+func (a *adapter) UpdateFilteredPolicies(sec string, ptype string, newPolicies [][]string, fieldIndex int, fieldValues ...string) ([][]string, error) {
+	q := a.newQuery().Where("p_type", "==", ptype)
+	for k, v := range filterSelector(fieldIndex, fieldValues) {
 		q = q.Where(k, "==", v)
 	}
 
-	return a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+	newLines := make([]CasbinRule, len(newPolicies))
+	for i, rule := range newPolicies {
+		newLines[i] = savePolicyLine(ptype, rule)
+	}
+
+	var oldRules [][]string
+	ctx := context.Background()
+	policies := a.client.Collection(a.collection)
+	err := a.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		oldRules = nil
 		iter := tx.Documents(q)
 		for {
 			doc, err := iter.Next()
@@ -223,82 +621,132 @@ func (a *adapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int,
 			if err != nil {
 				return err
 			}
+			var line CasbinRule
+			if err = doc.DataTo(&line); err != nil {
+				return err
+			}
+			oldRules = append(oldRules, ruleTokens(line))
 			if err = tx.Delete(doc.Ref); err != nil {
 				return err
 			}
 		}
+
+		for _, line := range newLines {
+			line := line
+			if err := tx.Create(policies.NewDoc(), &line); err != nil {
+				return err
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	a.invalidateCache()
+	return oldRules, nil
 }
 
-func savePolicyLine(ptype string, rule []string) CasbinRule {
-	line := CasbinRule{
-		PType: ptype,
+// ruleQuery builds the equality query that uniquely identifies line, as used
+// by RemovePolicy. It constrains every field the rule has (via N and each
+// values.N), so, unlike the old fixed v0..v4 comparison, a rule is never
+// mismatched on a trailing field.
+func (a *adapter) ruleQuery(line CasbinRule) firestore.Query {
+	q := a.newQuery().
+		Where("p_type", "==", line.PType).
+		Where("n", "==", line.N)
+	for i := 0; i < line.N; i++ {
+		q = q.Where(fmt.Sprintf("values.%d", i), "==", line.Values[strconv.Itoa(i)])
 	}
+	return q
+}
 
-	if len(rule) > 0 {
-		line.V0 = rule[0]
-	}
-	if len(rule) > 1 {
-		line.V1 = rule[1]
-	}
-	if len(rule) > 2 {
-		line.V2 = rule[2]
-	}
-	if len(rule) > 3 {
-		line.V3 = rule[3]
-	}
-	if len(rule) > 4 {
-		line.V4 = rule[4]
+// deleteMatching deletes every document matched by q within tx.
+func (a *adapter) deleteMatching(ctx context.Context, tx *firestore.Transaction, q firestore.Query) error {
+	refs, err := a.matchingRefs(ctx, tx, q)
+	if err != nil {
+		return err
 	}
-	if len(rule) > 5 {
-		line.V5 = rule[5]
+	for _, ref := range refs {
+		if err := tx.Delete(ref); err != nil {
+			return err
+		}
 	}
-
-	return line
+	return nil
 }
 
-func loadPolicyLine(line CasbinRule, model model.Model) {
-	key := line.PType
-	sec := key[:1]
-
-	tokens := []string{}
-	if line.V0 != "" {
-		tokens = append(tokens, line.V0)
-	} else {
-		goto LineEnd
+// matchingRefs returns the refs of every document matched by q within tx,
+// without deleting them, so a caller that needs to query more than one q
+// within the same transaction can gather every ref first and only then
+// start writing.
+func (a *adapter) matchingRefs(ctx context.Context, tx *firestore.Transaction, q firestore.Query) ([]*firestore.DocumentRef, error) {
+	var refs []*firestore.DocumentRef
+	iter := tx.Documents(q)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, doc.Ref)
 	}
+	return refs, nil
+}
 
-	if line.V1 != "" {
-		tokens = append(tokens, line.V1)
-	} else {
-		goto LineEnd
+// chunkRuleLines splits lines into chunks of at most size so a batch of
+// writes can be issued as several transactions, each within Firestore's
+// per-transaction write limit.
+func chunkRuleLines(lines []CasbinRule, size int) [][]CasbinRule {
+	var chunks [][]CasbinRule
+	for len(lines) > size {
+		chunks = append(chunks, lines[:size:size])
+		lines = lines[size:]
 	}
+	return append(chunks, lines)
+}
 
-	if line.V2 != "" {
-		tokens = append(tokens, line.V2)
-	} else {
-		goto LineEnd
+// chunkIndices returns the indices [0, n) split into chunks of at most size,
+// used to batch parallel oldRules/newRules slices together.
+func chunkIndices(n, size int) [][]int {
+	indices := make([]int, n)
+	for i := range indices {
+		indices[i] = i
 	}
-
-	if line.V3 != "" {
-		tokens = append(tokens, line.V3)
-	} else {
-		goto LineEnd
+	var chunks [][]int
+	for len(indices) > size {
+		chunks = append(chunks, indices[:size:size])
+		indices = indices[size:]
 	}
+	return append(chunks, indices)
+}
 
-	if line.V4 != "" {
-		tokens = append(tokens, line.V4)
-	} else {
-		goto LineEnd
+func savePolicyLine(ptype string, rule []string) CasbinRule {
+	values := make(map[string]string, len(rule))
+	for i, v := range rule {
+		values[strconv.Itoa(i)] = v
 	}
 
-	if line.V5 != "" {
-		tokens = append(tokens, line.V5)
-	} else {
-		goto LineEnd
+	return CasbinRule{
+		PType:  ptype,
+		N:      len(rule),
+		Values: values,
 	}
+}
 
-LineEnd:
-	model[sec][key].Policy = append(model[sec][key].Policy, tokens)
+func loadPolicyLine(line CasbinRule, model model.Model) {
+	key := line.PType
+	sec := key[:1]
+	model[sec][key].Policy = append(model[sec][key].Policy, ruleTokens(line))
+}
+
+// ruleTokens returns line's values as the []string Casbin expects, in
+// position order.
+func ruleTokens(line CasbinRule) []string {
+	tokens := make([]string, line.N)
+	for i := range tokens {
+		tokens[i] = line.Values[strconv.Itoa(i)]
+	}
+	return tokens
 }
@@ -8,6 +8,8 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
 )
 
 func getClient() *firestore.Client {
@@ -177,6 +179,107 @@ func TestAdapter(t *testing.T) {
 	})
 }
 
+func TestLoadFilteredPolicy(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-filtered"}
+	initPolicy(t, config)
+
+	a := NewAdapterWithConfig(getClient(), config)
+	m, err := model.NewModelFromFile("examples/rbac_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.(persist.FilteredAdapter).IsFiltered() {
+		t.Error("got filtered, wants not filtered before any load")
+	}
+
+	if err := a.(persist.FilteredAdapter).LoadFilteredPolicy(m, Filter{V0: []string{"alice"}}); err != nil {
+		t.Errorf("Expected LoadFilteredPolicy() to be successful; got %v", err)
+	}
+	if !a.(persist.FilteredAdapter).IsFiltered() {
+		t.Error("got not filtered, wants filtered after LoadFilteredPolicy")
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m), [][]string{{"alice", "data1", "read"}}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+}
+
+func casbinEnforcerFromModel(t *testing.T, m model.Model) *casbin.Enforcer {
+	e, err := casbin.NewEnforcer(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestBatchAndUpdatableAdapter(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-batch"}
+	a := NewAdapterWithConfig(getClient(), config)
+	batch := a.(persist.BatchAdapter)
+	updatable := a.(persist.UpdatableAdapter)
+
+	m, err := model.NewModelFromFile("examples/rbac_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := [][]string{
+		{"alice", "data1", "read"},
+		{"bob", "data2", "write"},
+		{"data2_admin", "data2", "read"},
+	}
+	if err := batch.AddPolicies("p", "p", rules); err != nil {
+		t.Errorf("Expected AddPolicies() to be successful; got %v", err)
+	}
+	if err := a.LoadPolicy(m); err != nil {
+		t.Errorf("Expected LoadPolicy() to be successful; got %v", err)
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m), rules, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+
+	if err := updatable.UpdatePolicy("p", "p", []string{"alice", "data1", "read"}, []string{"alice", "data1", "write"}); err != nil {
+		t.Errorf("Expected UpdatePolicy() to be successful; got %v", err)
+	}
+	m, _ = model.NewModelFromFile("examples/rbac_model.conf")
+	if err := a.LoadPolicy(m); err != nil {
+		t.Errorf("Expected LoadPolicy() to be successful; got %v", err)
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m), [][]string{
+		{"alice", "data1", "write"},
+		{"bob", "data2", "write"},
+		{"data2_admin", "data2", "read"},
+	}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+
+	if err := batch.RemovePolicies("p", "p", [][]string{{"alice", "data1", "write"}, {"bob", "data2", "write"}}); err != nil {
+		t.Errorf("Expected RemovePolicies() to be successful; got %v", err)
+	}
+	m, _ = model.NewModelFromFile("examples/rbac_model.conf")
+	if err := a.LoadPolicy(m); err != nil {
+		t.Errorf("Expected LoadPolicy() to be successful; got %v", err)
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m), [][]string{{"data2_admin", "data2", "read"}}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+
+	old, err := updatable.UpdateFilteredPolicies("p", "p", [][]string{{"data2_admin", "data2", "write"}}, 0, "data2_admin")
+	if err != nil {
+		t.Errorf("Expected UpdateFilteredPolicies() to be successful; got %v", err)
+	}
+	if !SamePolicy(old, [][]string{{"data2_admin", "data2", "read"}}) {
+		t.Error("got old rules: ", old, ", wants ", [][]string{{"data2_admin", "data2", "read"}})
+	}
+	m, _ = model.NewModelFromFile("examples/rbac_model.conf")
+	if err := a.LoadPolicy(m); err != nil {
+		t.Errorf("Expected LoadPolicy() to be successful; got %v", err)
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m), [][]string{{"data2_admin", "data2", "write"}}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+}
+
 func TestDeleteFilteredAdapter(t *testing.T) {
 	a := NewAdapter(getClient())
 	e, _ := casbin.NewEnforcer("examples/rbac_tenant_service.conf", a)
@@ -0,0 +1,33 @@
+package firestoreadapter
+
+import "time"
+
+// Cache lets LoadPolicy skip a Firestore read of the whole collection when
+// nothing has changed since the last load. Implementations are expected to
+// be safe for concurrent use, since an adapter may be shared across
+// goroutines (e.g. behind a casbin.SyncedEnforcer).
+//
+// A cached entry cannot detect a rule being deleted by anything other than
+// an adapter that invalidates this same Cache: removing a document doesn't
+// advance any remaining document's update_time, so the
+// OrderBy("update_time", Desc).Limit(1) staleness check has nothing newer to
+// notice. Such an entry keeps serving the already-deleted rule until some
+// other write to the collection bumps update_time past the cached value.
+// CacheTTL does not bound this: once it elapses, the same check runs again.
+type Cache interface {
+	// Get returns the rules cached for collection, the latest update_time
+	// they were valid as of, and when they were cached. ok is false if
+	// nothing is cached.
+	Get(collection string) (rules []CasbinRule, updateTime time.Time, cachedAt time.Time, ok bool)
+
+	// Set caches rules for collection as valid as of updateTime, the
+	// greatest update_time among them, having been cached at cachedAt.
+	Set(collection string, rules []CasbinRule, updateTime time.Time, cachedAt time.Time)
+
+	// Invalidate discards any entry cached for collection. The adapter calls
+	// this after each of its own writes to the collection, so a cache
+	// shared across instances is still only relied on for the staleness
+	// window between external writes and the next OrderBy("update_time",
+	// Desc).Limit(1) check.
+	Invalidate(collection string)
+}
@@ -0,0 +1,73 @@
+package firestoreadapter
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2/model"
+)
+
+// memoryCache is a minimal Cache used to exercise Config.Cache in tests.
+type memoryCache struct {
+	mu         sync.Mutex
+	rules      []CasbinRule
+	updateTime time.Time
+	cachedAt   time.Time
+	ok         bool
+}
+
+func (c *memoryCache) Get(collection string) ([]CasbinRule, time.Time, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rules, c.updateTime, c.cachedAt, c.ok
+}
+
+func (c *memoryCache) Set(collection string, rules []CasbinRule, updateTime, cachedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules, c.updateTime, c.cachedAt, c.ok = rules, updateTime, cachedAt, true
+}
+
+func (c *memoryCache) Invalidate(collection string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ok = false
+}
+
+func TestLoadPolicyWithCache(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-cache"}
+	initPolicy(t, config)
+
+	cache := &memoryCache{}
+	a := NewAdapterWithConfig(getClient(), Config{Collection: config.Collection, Cache: cache})
+
+	m, err := model.NewModelFromFile("examples/rbac_model.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.LoadPolicy(m); err != nil {
+		t.Fatalf("Expected LoadPolicy() to be successful; got %v", err)
+	}
+	if _, _, _, ok := cache.Get(config.Collection); !ok {
+		t.Error("expected LoadPolicy() to populate the cache")
+	}
+
+	// A second LoadPolicy should be served from the cache without error,
+	// and return the same rules.
+	m2, _ := model.NewModelFromFile("examples/rbac_model.conf")
+	if err := a.LoadPolicy(m2); err != nil {
+		t.Errorf("Expected cached LoadPolicy() to be successful; got %v", err)
+	}
+	testGetPolicy(casbinEnforcerFromModel(t, m2), casbinEnforcerFromModel(t, m).GetPolicy(), func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+
+	// Writing through the adapter must invalidate the cache.
+	if err := a.AddPolicy("p", "p", []string{"cache-test", "data1", "read"}); err != nil {
+		t.Fatalf("Expected AddPolicy() to be successful; got %v", err)
+	}
+	if _, _, _, ok := cache.Get(config.Collection); ok {
+		t.Error("expected AddPolicy() to invalidate the cache")
+	}
+}
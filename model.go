@@ -6,6 +6,9 @@ import (
 
 	"cloud.google.com/go/firestore"
 	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	fileadapter "github.com/casbin/casbin/v2/persist/file-adapter"
+	"google.golang.org/api/iterator"
 )
 
 type CasbinModelConf struct {
@@ -59,3 +62,92 @@ func LoadModelWithConfig(client *firestore.Client, config Config) (model.Model,
 
 	return model.NewModelFromString(conf.Text)
 }
+
+// NewAdapterFromFiles returns an Adapter backed by config's Firestore
+// collection, bootstrapping it the first time that collection is empty by
+// loading modelPath/policyPath through Casbin's file adapter and seeding the
+// model and policy into Firestore together in a single transaction, so a
+// failure partway through never leaves the conf document written with no
+// policy (or vice versa). This automates the "bootstrap a CSV baseline,
+// then manage policy in the DB" workflow, replacing the manual
+// save-then-clear trick otherwise needed to seed a fresh collection. If the
+// collection already holds policy, the files are ignored and the existing
+// data in Firestore is used as-is.
+func NewAdapterFromFiles(client *firestore.Client, modelPath, policyPath string, config Config) (persist.Adapter, error) {
+	a := NewAdapterWithConfig(client, config)
+
+	empty, err := isCollectionEmpty(client, config)
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return a, nil
+	}
+
+	text, err := ioutil.ReadFile(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	m, err := model.NewModelFromString(string(text))
+	if err != nil {
+		return nil, err
+	}
+	if err = fileadapter.NewAdapter(policyPath).LoadPolicy(m); err != nil {
+		return nil, err
+	}
+
+	if err = seedModelAndPolicy(client, config, string(text), m); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// seedModelAndPolicy writes text (the model definition) and m's policy rules
+// to config's collection in a single transaction, as the one-time bootstrap
+// NewAdapterFromFiles performs against an empty collection.
+func seedModelAndPolicy(client *firestore.Client, config Config, text string, m model.Model) error {
+	var lines []interface{}
+	for ptype, ast := range m["p"] {
+		for _, rule := range ast.Policy {
+			line := savePolicyLine(ptype, rule)
+			lines = append(lines, &line)
+		}
+	}
+	for ptype, ast := range m["g"] {
+		for _, rule := range ast.Policy {
+			line := savePolicyLine(ptype, rule)
+			lines = append(lines, &line)
+		}
+	}
+
+	ctx := context.Background()
+	collection := client.Collection(config.collectionName())
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		conf := CasbinModelConf{text}
+		if err := tx.Set(collection.Doc("conf"), &conf); err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := tx.Create(collection.NewDoc(), &line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// isCollectionEmpty reports whether config's collection has no policy
+// documents yet.
+func isCollectionEmpty(client *firestore.Client, config Config) (bool, error) {
+	ctx := context.Background()
+	iter := client.Collection(config.collectionName()).Where("p_type", ">", "").Limit(1).Documents(ctx)
+	_, err := iter.Next()
+	if err == iterator.Done {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
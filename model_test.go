@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/casbin/casbin/v2"
 	"github.com/casbin/casbin/v2/model"
 )
 
@@ -49,6 +50,41 @@ func TestSaveInvalidFile(t *testing.T) {
 	}
 }
 
+func TestNewAdapterFromFiles(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-bootstrap"}
+	client := getClient()
+
+	a, err := NewAdapterFromFiles(client, "examples/rbac_model.conf", "examples/rbac_policy.csv", config)
+	if err != nil {
+		t.Fatalf("Expected NewAdapterFromFiles() to be successful; got %v", err)
+	}
+
+	e, err := casbin.NewEnforcer("examples/rbac_model.conf", a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(e, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+
+	// A second call finds the collection already seeded: the enforcer it
+	// backs should see the data in Firestore, including a rule added since.
+	if err := a.AddPolicy("p", "p", []string{"charlie", "data3", "read"}); err != nil {
+		t.Fatalf("Expected AddPolicy() to be successful; got %v", err)
+	}
+	a2, err := NewAdapterFromFiles(client, "examples/rbac_model.conf", "examples/rbac_policy.csv", config)
+	if err != nil {
+		t.Fatalf("Expected second NewAdapterFromFiles() to be successful; got %v", err)
+	}
+	e2, err := casbin.NewEnforcer("examples/rbac_model.conf", a2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGetPolicy(e2, [][]string{{"alice", "data1", "read"}, {"bob", "data2", "write"}, {"data2_admin", "data2", "read"}, {"data2_admin", "data2", "write"}, {"charlie", "data3", "read"}}, func(actual, wants [][]string) {
+		t.Error("got: ", actual, ", wants ", wants)
+	})
+}
+
 func TestLoadModelFail(t *testing.T) {
 	db := getClient()
 	config := Config{
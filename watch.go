@@ -0,0 +1,120 @@
+package firestoreadapter
+
+import (
+	"context"
+	"errors"
+
+	"cloud.google.com/go/firestore"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+// Watch streams changes to the policy collection via a Firestore snapshot
+// listener. On every snapshot that carries DocumentAdded/Modified/Removed
+// changes it builds a fresh model from this adapter's collection and invokes
+// onChange with it once, so callers can keep a running enforcer in sync
+// without polling. The model definition is read once, from the collection's
+// "conf" document (see SaveModelWithConfig); Watch returns an error if that
+// document does not exist. A new model is built per snapshot, rather than
+// one shared, mutated instance, since
+// onChange may hand its argument off to something that reads it after
+// returning (e.g. an enforcer holding onto it) - reusing one instance would
+// race with the next event clearing and repopulating it. Call the returned
+// stop func to end the stream.
+func (a *adapter) Watch(ctx context.Context, onChange func(model.Model)) (stop func(), err error) {
+	ref := a.client.Collection(a.collection).Doc("conf")
+	docsnap, err := ref.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var conf CasbinModelConf
+	if err = docsnap.DataTo(&conf); err != nil {
+		return nil, err
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	it := a.newQuery().Snapshots(watchCtx)
+
+	rules := make(map[string]CasbinRule)
+
+	go func() {
+		defer it.Stop()
+		for {
+			snap, err := it.Next()
+			if err != nil {
+				return
+			}
+			if len(snap.Changes) == 0 {
+				continue
+			}
+
+			for _, change := range snap.Changes {
+				switch change.Kind {
+				case firestore.DocumentAdded, firestore.DocumentModified:
+					var rule CasbinRule
+					if err := change.Doc.DataTo(&rule); err != nil {
+						continue
+					}
+					rules[change.Doc.Ref.ID] = rule
+				case firestore.DocumentRemoved:
+					delete(rules, change.Doc.Ref.ID)
+				}
+			}
+
+			m, err := model.NewModelFromString(conf.Text)
+			if err != nil {
+				continue
+			}
+			for _, rule := range rules {
+				loadPolicyLine(rule, m)
+			}
+			onChange(m)
+		}
+	}()
+
+	return cancel, nil
+}
+
+// watcher adapts adapter's native Watch stream into Casbin's persist.Watcher
+// interface, the mechanism casbin.SyncedEnforcer.SetWatcher expects.
+type watcher struct {
+	callback func(string)
+	stop     func()
+}
+
+// NewSyncedWatcher starts watching db's policy collection and returns a
+// persist.Watcher that can be registered with
+// casbin.SyncedEnforcer.SetWatcher, so every instance sharing the collection
+// picks up changes pushed by any other instance without polling.
+func NewSyncedWatcher(ctx context.Context, db *firestore.Client, config Config) (persist.Watcher, error) {
+	a := &adapter{client: db, collection: config.collectionName()}
+
+	w := &watcher{}
+	stop, err := a.Watch(ctx, func(model.Model) {
+		if w.callback != nil {
+			w.callback("")
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.stop = stop
+
+	return w, nil
+}
+
+// SetUpdateCallback implements persist.Watcher.
+func (w *watcher) SetUpdateCallback(callback func(string)) error {
+	w.callback = callback
+	return nil
+}
+
+// Update implements persist.Watcher. Firestore is the source of truth for
+// this adapter, so there is nothing to push on a local policy change; the
+// write already went through Firestore and will come back through Watch.
+func (w *watcher) Update() error {
+	if w.callback == nil {
+		return errors.New("update callback not set")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package firestoreadapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2/model"
+)
+
+func TestWatch(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-watch"}
+	initPolicy(t, config)
+	if err := SaveModelWithConfig(getClient(), "examples/rbac_model.conf", config); err != nil {
+		t.Fatalf("Expected SaveModelWithConfig() to be successful; got %v", err)
+	}
+
+	a := NewAdapterWithConfig(getClient(), config).(*adapter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan model.Model, 1)
+	stop, err := a.Watch(ctx, func(m model.Model) {
+		select {
+		case changes <- m:
+		default:
+		}
+	})
+	if err != nil {
+		t.Fatalf("Expected Watch() to be successful; got %v", err)
+	}
+	defer stop()
+
+	if err := a.AddPolicy("p", "p", []string{"watch", "data9", "read"}); err != nil {
+		t.Fatalf("Expected AddPolicy() to be successful; got %v", err)
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(10 * time.Second):
+		t.Error("expected onChange to fire after a policy change")
+	}
+}
+
+func TestNewSyncedWatcher(t *testing.T) {
+	config := Config{Collection: "firestoreadapter-unittest-watch"}
+	if err := SaveModelWithConfig(getClient(), "examples/rbac_model.conf", config); err != nil {
+		t.Fatalf("Expected SaveModelWithConfig() to be successful; got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w, err := NewSyncedWatcher(ctx, getClient(), config)
+	if err != nil {
+		t.Fatalf("Expected NewSyncedWatcher() to be successful; got %v", err)
+	}
+
+	received := make(chan struct{}, 1)
+	if err := w.SetUpdateCallback(func(string) {
+		select {
+		case received <- struct{}{}:
+		default:
+		}
+	}); err != nil {
+		t.Errorf("Expected SetUpdateCallback() to be successful; got %v", err)
+	}
+
+	a := NewAdapterWithConfig(getClient(), config)
+	if err := a.AddPolicy("p", "p", []string{"alice", "data1", "read"}); err != nil {
+		t.Fatalf("Expected AddPolicy() to be successful; got %v", err)
+	}
+
+	select {
+	case <-received:
+	case <-time.After(10 * time.Second):
+		t.Error("expected the watcher's update callback to fire after a policy change")
+	}
+
+	cancel()
+}